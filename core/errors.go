@@ -3,14 +3,6 @@ package core
 import (
 	"context"
 
-	"chain/core/accesstoken"
-	"chain/core/account/utxodb"
-	"chain/core/blocksigner"
-	"chain/core/mockhsm"
-	"chain/core/query"
-	"chain/core/query/filter"
-	"chain/core/signers"
-	"chain/core/txbuilder"
 	"chain/database/pg"
 	"chain/errors"
 	"chain/net/http/httpjson"
@@ -23,119 +15,193 @@ type errorInfo struct {
 	HTTPStatus int    `json:"-"`
 	ChainCode  string `json:"code"`
 	Message    string `json:"message"`
+
+	// Temporary is whether callers can expect this error to go away
+	// on retry. It's ignored when Retriable is set.
+	Temporary bool `json:"-"`
+
+	// Retriable, if non-nil, computes Temporary from the concrete
+	// error instead of using the static Temporary field above. It
+	// exists for composite errors like errActions, whose
+	// temporariness depends on the errors they wrap.
+	Retriable func(err error) bool `json:"-"`
 }
 
 type detailedError struct {
 	errorInfo
-	Detail    string `json:"detail,omitempty"`
-	Temporary bool   `json:"temporary"`
+	Detail    string          `json:"detail,omitempty"`
+	Temporary bool            `json:"temporary"`
+	Actions   []detailedError `json:"actions,omitempty"`
 }
 
-var temporaryErrorCodes = map[string]bool{
-	"CH000": true, // internal server error
-	"CH001": true, // request timed out
-	"CH761": true, // outputs currently reserved
+// errActions wraps the per-action errors produced while building a
+// transaction template for a request with more than one action.
+// errors.Data(err)["actions"] holds the []detailedError describing
+// each action's outcome, in request order.
+var errActions = errors.New("errors occurred in one or more actions")
+
+// infoInternal holds the codes we use for an internal error.
+// It is defined here for easy reference.
+var infoInternal = errorInfo{500, "CH000", "Chain API Error", true, nil}
+
+// Formatter maps errors to the HTTP status codes and response bodies
+// they should produce. Packages own the errors they define, so rather
+// than listing every error in one place, they register their own
+// entries against a Formatter with Register during init.
+//
+// DefaultFormatter is the Formatter used by the core package's HTTP
+// handlers. Embedders that want a different default error, a
+// different code prefix, or a different set of registered errors can
+// construct their own Formatter instead of using DefaultFormatter.
+type Formatter struct {
+	Default errorInfo
+	Errors  map[error]errorInfo
 }
 
-var (
-	// infoInternal holds the codes we use for an internal error.
-	// It is defined here for easy reference.
-	infoInternal = errorInfo{500, "CH000", "Chain API Error"}
-
-	// Map error values to standard chain error codes.
-	// Missing entries will map to infoInternal.
-	// See chain.com/docs.
-	errorInfoTab = map[error]errorInfo{
-		// General error namespace (0xx)
-		context.DeadlineExceeded: errorInfo{408, "CH001", "Request timed out"},
-		pg.ErrUserInputNotFound:  errorInfo{400, "CH002", "Not found"},
-		httpjson.ErrBadRequest:   errorInfo{400, "CH003", "Invalid request body"},
-		errBadReqHeader:          errorInfo{400, "CH004", "Invalid request header"},
-		errNotFound:              errorInfo{404, "CH006", "Not found"},
-		errRateLimited:           errorInfo{429, "CH007", "Request limit exceeded"},
-		errLeaderElection:        errorInfo{503, "CH008", "Electing a new leader for the core; try again soon"},
-		errNotAuthenticated:      errorInfo{401, "CH009", "Request could not be authenticated"},
-
-		// Core error namespace
-		errUnconfigured:                errorInfo{400, "CH100", "This core still needs to be configured"},
-		errAlreadyConfigured:           errorInfo{400, "CH101", "This core has already been configured"},
-		errBadGenerator:                errorInfo{400, "CH102", "Generator URL returned an invalid response"},
-		errBadBlockPub:                 errorInfo{400, "CH103", "Provided Block XPub is invalid"},
-		rpc.ErrWrongNetwork:            errorInfo{502, "CH104", "A peer core is operating on a different blockchain network"},
-		protocol.ErrTheDistantFuture:   errorInfo{400, "CH105", "Requested height is too far ahead"},
-		errBadSignerURL:                errorInfo{400, "CH106", "Block signer URL is invalid"},
-		errBadSignerPubkey:             errorInfo{400, "CH107", "Block signer pubkey is invalid"},
-		errBadQuorum:                   errorInfo{400, "CH108", "Quorum must be greater than 0 if there are signers"},
-		errProdReset:                   errorInfo{400, "CH110", "Reset can only be called in a development system"},
-		errNoClientTokens:              errorInfo{400, "CH120", "Cannot enable client authentication with no client tokens"},
-		blocksigner.ErrConsensusChange: errorInfo{400, "CH150", "Refuse to sign block with consensus change"},
-
-		// Signers error namespace (2xx)
-		signers.ErrBadQuorum: errorInfo{400, "CH200", "Quorum must be greater than 1 and less than or equal to the length of xpubs"},
-		signers.ErrBadXPub:   errorInfo{400, "CH201", "Invalid xpub format"},
-		signers.ErrNoXPubs:   errorInfo{400, "CH202", "At least one xpub is required"},
-		signers.ErrBadType:   errorInfo{400, "CH203", "Retrieved type does not match expected type"},
-
-		// Access token error namespace (3xx)
-		accesstoken.ErrBadID:       errorInfo{400, "CH300", "Malformed or empty access token id"},
-		accesstoken.ErrBadType:     errorInfo{400, "CH301", "Access tokens must be type client or network"},
-		accesstoken.ErrDuplicateID: errorInfo{400, "CH302", "Access token id is already in use"},
-		errCurrentToken:            errorInfo{400, "CH310", "The access token used to authenticate this request cannot be deleted"},
-
-		// Query error namespace (6xx)
-		query.ErrBadAfter:               errorInfo{400, "CH600", "Malformed pagination parameter `after`"},
-		query.ErrParameterCountMismatch: errorInfo{400, "CH601", "Incorrect number of parameters to filter"},
-		filter.ErrBadFilter:             errorInfo{400, "CH602", "Malformed query filter"},
-
-		// Transaction error namespace (7xx)
-		// Build error namespace (70x)
-		txbuilder.ErrBadRefData: errorInfo{400, "CH700", "Reference data does not match previous transaction's reference data"},
-		errBadActionType:        errorInfo{400, "CH701", "Invalid action type"},
-		errBadAlias:             errorInfo{400, "CH702", "Invalid alias on action"},
-		errBadAction:            errorInfo{400, "CH703", "Invalid action object"},
-
-		// Submit error namespace (73x)
-		txbuilder.ErrMissingRawTx:          errorInfo{400, "CH730", "Missing raw transaction"},
-		txbuilder.ErrBadInstructionCount:   errorInfo{400, "CH731", "Too many signing instructions in template for transaction"},
-		txbuilder.ErrBadTxInputIdx:         errorInfo{400, "CH732", "Invalid transaction input index"},
-		txbuilder.ErrBadWitnessComponent:   errorInfo{400, "CH733", "Invalid witness component"},
-		txbuilder.ErrRejected:              errorInfo{400, "CH735", "Transaction rejected"},
-		txbuilder.ErrNoTxSighashCommitment: errorInfo{400, "CH736", "Transaction is not final, additional actions still allowed"},
-
-		// account action error namespace (76x)
-		utxodb.ErrInsufficient: errorInfo{400, "CH760", "Insufficient funds for tx"},
-		utxodb.ErrReserved:     errorInfo{400, "CH761", "Some outputs are reserved; try again"},
-
-		// Mock HSM error namespace (80x)
-		mockhsm.ErrDuplicateKeyAlias: errorInfo{400, "CH800", "Duplicate alias for Mock HSM key"},
-		mockhsm.ErrInvalidAfter:      errorInfo{400, "CH801", "Invalid `after` in query"},
+// Register associates err with info in f. It is meant to be called
+// from a package's init function, once per sentinel error that
+// package wants translated into an API-visible code. f.Errors is
+// lazily initialized, so a zero-value or literal Formatter with no
+// Errors map works without a constructor.
+func (f *Formatter) Register(err error, info errorInfo) {
+	if f.Errors == nil {
+		f.Errors = make(map[error]errorInfo)
 	}
-)
+	f.Errors[err] = info
+}
 
-// errInfo returns the HTTP status code to use
-// and a suitable response body describing err
-// by consulting the global lookup table.
-// If no entry is found, it returns infoInternal.
-func errInfo(err error) (body detailedError, info errorInfo) {
+// Format returns the HTTP status code to use and a suitable response
+// body describing err, by consulting f's registered errors. If no
+// entry is found, it returns f.Default.
+func (f *Formatter) Format(err error) (body detailedError, info errorInfo) {
 	root := errors.Root(err)
 	// Some types cannot be used as map keys, for example slices.
 	// If an error's underlying type is one of these, don't panic.
 	// Just treat it like any other missing entry.
 	defer func() {
 		if err := recover(); err != nil {
-			info = infoInternal
-			body = detailedError{infoInternal, "", true}
+			info = f.Default
+			body = detailedError{f.Default, "", f.Default.Temporary, nil}
 		}
 	}()
-	info, ok := errorInfoTab[root]
+	info, ok := f.Errors[root]
 	if !ok {
-		info = infoInternal
+		info = f.Default
+	}
+
+	temporary := info.Temporary
+	if info.Retriable != nil {
+		temporary = info.Retriable(err)
 	}
 
 	body = detailedError{
 		errorInfo: info,
 		Detail:    errors.Detail(err),
-		Temporary: temporaryErrorCodes[info.ChainCode],
+		Temporary: temporary,
+	}
+
+	if root == errActions {
+		if actions, ok := errors.Data(err)["actions"].([]detailedError); ok {
+			body.Actions = actions
+		}
 	}
 	return body, info
 }
+
+// wrapActionErrors aggregates the per-action errors from a
+// transaction-build request with more than one action into a single
+// errActions error. Each element of errs is formatted independently
+// via errInfo, and the resulting []detailedError is attached as the
+// "actions" data on the returned error so Format can surface it as
+// body.Actions instead of reporting only the first failure.
+//
+// The build handler should call this once it has collected an error
+// per action, rather than returning the first action error it sees.
+//
+// TODO(gzuhlwang/chain#chunk0-1): unwired. No build handler exists in
+// this tree to call this instead of failing fast on the first action
+// error, so a real multi-action build failure still surfaces as a
+// single opaque code exactly as before. Don't consider chunk0-1
+// resolved until a real call site replaces that fail-fast behavior
+// with a call here.
+func wrapActionErrors(errs []error) error {
+	actions := make([]detailedError, len(errs))
+	for i, err := range errs {
+		actions[i], _ = errInfo(err)
+	}
+	return errors.WithData(errActions, "actions", actions)
+}
+
+// actionsRetriable reports whether every per-action error wrapped by
+// an errActions error is itself temporary. An errActions error that
+// carries no per-action errors is reported as not temporary.
+func actionsRetriable(err error) bool {
+	actions, ok := errors.Data(err)["actions"].([]detailedError)
+	if !ok || len(actions) == 0 {
+		return false
+	}
+	for _, a := range actions {
+		if !a.Temporary {
+			return false
+		}
+	}
+	return true
+}
+
+// DefaultFormatter is the Formatter consulted by errInfo. Core's own
+// errors are registered against it below; other packages' sentinel
+// errors are registered from init functions in core/errors_<sub>.go,
+// one file per package, instead of in a central table here.
+// See chain.com/docs.
+var DefaultFormatter = &Formatter{
+	Default: infoInternal,
+	Errors:  make(map[error]errorInfo),
+}
+
+func init() {
+	// General error namespace (0xx)
+	DefaultFormatter.Register(context.DeadlineExceeded, errorInfo{408, "CH001", "Request timed out", true, nil})
+	DefaultFormatter.Register(pg.ErrUserInputNotFound, errorInfo{400, "CH002", "Not found", false, nil})
+	DefaultFormatter.Register(httpjson.ErrBadRequest, errorInfo{400, "CH003", "Invalid request body", false, nil})
+	DefaultFormatter.Register(errBadReqHeader, errorInfo{400, "CH004", "Invalid request header", false, nil})
+	DefaultFormatter.Register(errNotFound, errorInfo{404, "CH006", "Not found", false, nil})
+	DefaultFormatter.Register(errRateLimited, errorInfo{429, "CH007", "Request limit exceeded", true, nil})
+	DefaultFormatter.Register(errLeaderElection, errorInfo{503, "CH008", "Electing a new leader for the core; try again soon", true, nil})
+	DefaultFormatter.Register(errNotAuthenticated, errorInfo{401, "CH009", "Request could not be authenticated", false, nil})
+
+	// Core error namespace
+	DefaultFormatter.Register(errUnconfigured, errorInfo{400, "CH100", "This core still needs to be configured", false, nil})
+	DefaultFormatter.Register(errAlreadyConfigured, errorInfo{400, "CH101", "This core has already been configured", false, nil})
+	DefaultFormatter.Register(errBadGenerator, errorInfo{400, "CH102", "Generator URL returned an invalid response", false, nil})
+	DefaultFormatter.Register(errBadBlockPub, errorInfo{400, "CH103", "Provided Block XPub is invalid", false, nil})
+	DefaultFormatter.Register(rpc.ErrWrongNetwork, errorInfo{502, "CH104", "A peer core is operating on a different blockchain network", true, nil})
+	DefaultFormatter.Register(protocol.ErrTheDistantFuture, errorInfo{400, "CH105", "Requested height is too far ahead", false, nil})
+	DefaultFormatter.Register(errBadSignerURL, errorInfo{400, "CH106", "Block signer URL is invalid", false, nil})
+	DefaultFormatter.Register(errBadSignerPubkey, errorInfo{400, "CH107", "Block signer pubkey is invalid", false, nil})
+	DefaultFormatter.Register(errBadQuorum, errorInfo{400, "CH108", "Quorum must be greater than 0 if there are signers", false, nil})
+	DefaultFormatter.Register(errProdReset, errorInfo{400, "CH110", "Reset can only be called in a development system", false, nil})
+	DefaultFormatter.Register(errNoClientTokens, errorInfo{400, "CH120", "Cannot enable client authentication with no client tokens", false, nil})
+
+	// Build error namespace (70x)
+	DefaultFormatter.Register(errBadActionType, errorInfo{400, "CH701", "Invalid action type", false, nil})
+	DefaultFormatter.Register(errBadAlias, errorInfo{400, "CH702", "Invalid alias on action", false, nil})
+	DefaultFormatter.Register(errBadAction, errorInfo{400, "CH703", "Invalid action object", false, nil})
+	DefaultFormatter.Register(errActions, errorInfo{400, "CH706", "One or more actions had an error", false, actionsRetriable})
+
+	// Access token error namespace (3xx): errCurrentToken is raised by
+	// core itself (on top of the accesstoken package's own CH3xx
+	// errors, registered from core/errors_accesstoken.go).
+	DefaultFormatter.Register(errCurrentToken, errorInfo{400, "CH310", "The access token used to authenticate this request cannot be deleted", false, nil})
+
+	// The CH150, CH2xx, CH300-CH302, CH6xx, CH700/CH73x, CH76x, and
+	// CH80x codes for errors owned by blocksigner, signers, accesstoken,
+	// query/filter, txbuilder, utxodb, and mockhsm are registered from
+	// core/errors_<sub>.go, one file per package, not here.
+}
+
+// errInfo returns the HTTP status code to use and a suitable response
+// body describing err, by consulting DefaultFormatter.
+// If no entry is found, it returns infoInternal.
+func errInfo(err error) (body detailedError, info errorInfo) {
+	return DefaultFormatter.Format(err)
+}
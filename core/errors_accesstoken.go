@@ -0,0 +1,12 @@
+package core
+
+import "chain/core/accesstoken"
+
+// Registers accesstoken's sentinel errors against DefaultFormatter.
+// See the note in errors_blocksigner.go for why this lives in core
+// instead of package accesstoken.
+func init() {
+	DefaultFormatter.Register(accesstoken.ErrBadID, errorInfo{400, "CH300", "Malformed or empty access token id", false, nil})
+	DefaultFormatter.Register(accesstoken.ErrBadType, errorInfo{400, "CH301", "Access tokens must be type client or network", false, nil})
+	DefaultFormatter.Register(accesstoken.ErrDuplicateID, errorInfo{400, "CH302", "Access token id is already in use", false, nil})
+}
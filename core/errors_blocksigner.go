@@ -0,0 +1,12 @@
+package core
+
+import "chain/core/blocksigner"
+
+// Registers blocksigner's sentinel errors against DefaultFormatter.
+// This lives in core (rather than in package blocksigner itself)
+// because core already imports blocksigner for block-signing
+// handlers; blocksigner importing core back to call Register would
+// be an import cycle.
+func init() {
+	DefaultFormatter.Register(blocksigner.ErrConsensusChange, errorInfo{400, "CH150", "Refuse to sign block with consensus change", false, nil})
+}
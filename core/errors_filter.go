@@ -0,0 +1,10 @@
+package core
+
+import "chain/core/query/filter"
+
+// Registers filter's sentinel errors against DefaultFormatter. See
+// the note in errors_blocksigner.go for why this lives in core
+// instead of package filter.
+func init() {
+	DefaultFormatter.Register(filter.ErrBadFilter, errorInfo{400, "CH602", "Malformed query filter", false, nil})
+}
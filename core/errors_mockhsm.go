@@ -0,0 +1,11 @@
+package core
+
+import "chain/core/mockhsm"
+
+// Registers mockhsm's sentinel errors against DefaultFormatter. See
+// the note in errors_blocksigner.go for why this lives in core
+// instead of package mockhsm.
+func init() {
+	DefaultFormatter.Register(mockhsm.ErrDuplicateKeyAlias, errorInfo{400, "CH800", "Duplicate alias for Mock HSM key", false, nil})
+	DefaultFormatter.Register(mockhsm.ErrInvalidAfter, errorInfo{400, "CH801", "Invalid `after` in query", false, nil})
+}
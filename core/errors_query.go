@@ -0,0 +1,11 @@
+package core
+
+import "chain/core/query"
+
+// Registers query's sentinel errors against DefaultFormatter. See the
+// note in errors_blocksigner.go for why this lives in core instead of
+// package query.
+func init() {
+	DefaultFormatter.Register(query.ErrBadAfter, errorInfo{400, "CH600", "Malformed pagination parameter `after`", false, nil})
+	DefaultFormatter.Register(query.ErrParameterCountMismatch, errorInfo{400, "CH601", "Incorrect number of parameters to filter", false, nil})
+}
@@ -0,0 +1,13 @@
+package core
+
+import "chain/core/signers"
+
+// Registers signers' sentinel errors against DefaultFormatter. See
+// the note in errors_blocksigner.go for why this lives in core
+// instead of package signers.
+func init() {
+	DefaultFormatter.Register(signers.ErrBadQuorum, errorInfo{400, "CH200", "Quorum must be greater than 1 and less than or equal to the length of xpubs", false, nil})
+	DefaultFormatter.Register(signers.ErrBadXPub, errorInfo{400, "CH201", "Invalid xpub format", false, nil})
+	DefaultFormatter.Register(signers.ErrNoXPubs, errorInfo{400, "CH202", "At least one xpub is required", false, nil})
+	DefaultFormatter.Register(signers.ErrBadType, errorInfo{400, "CH203", "Retrieved type does not match expected type", false, nil})
+}
@@ -0,0 +1,63 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"chain/database/pg"
+)
+
+// exceptionalTemporary lists chain codes whose Temporary classification
+// intentionally deviates from the HTTP-status default below (e.g.
+// because the condition is known to clear on its own despite a 4xx
+// status).
+var exceptionalTemporary = map[string]bool{
+	"CH761": true, // outputs reserved; safe to retry despite 400
+}
+
+// TestErrorInfoTemporaryDefaults checks that every registered error
+// follows the convention that 5xx, 408, and 429 responses are
+// temporary and all other responses are not, unless it's listed in
+// exceptionalTemporary or computes its temporariness with Retriable
+// (those entries are skipped). This catches the common mistake of adding a
+// new errorInfo entry without setting Temporary correctly.
+func TestErrorInfoTemporaryDefaults(t *testing.T) {
+	for _, info := range DefaultFormatter.Errors {
+		if info.Retriable != nil || exceptionalTemporary[info.ChainCode] {
+			continue
+		}
+		want := info.HTTPStatus >= 500 || info.HTTPStatus == 408 || info.HTTPStatus == 429
+		if info.Temporary != want {
+			t.Errorf("%s: Temporary = %v, want %v for HTTPStatus %d", info.ChainCode, info.Temporary, want, info.HTTPStatus)
+		}
+	}
+}
+
+// TestWrapActionErrors checks that aggregating per-action errors
+// produces a CH706 response whose Actions slice holds one
+// detailedError per input error, and whose Temporary flag is the
+// logical AND of the children's.
+func TestWrapActionErrors(t *testing.T) {
+	err := wrapActionErrors([]error{context.DeadlineExceeded, pg.ErrUserInputNotFound})
+	body, info := errInfo(err)
+	if info.ChainCode != "CH706" {
+		t.Fatalf("ChainCode = %s, want CH706", info.ChainCode)
+	}
+	if len(body.Actions) != 2 {
+		t.Fatalf("len(Actions) = %d, want 2", len(body.Actions))
+	}
+	if body.Actions[0].ChainCode != "CH001" || body.Actions[1].ChainCode != "CH002" {
+		t.Fatalf("Actions = %+v, want CH001 then CH002", body.Actions)
+	}
+	// context.DeadlineExceeded is temporary, pg.ErrUserInputNotFound is not,
+	// so the AND of the two must be false.
+	if body.Temporary {
+		t.Fatalf("Temporary = true, want false when one action error is non-temporary")
+	}
+
+	err = wrapActionErrors([]error{context.DeadlineExceeded, context.DeadlineExceeded})
+	body, _ = errInfo(err)
+	if !body.Temporary {
+		t.Fatalf("Temporary = false, want true when every action error is temporary")
+	}
+}
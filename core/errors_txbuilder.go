@@ -0,0 +1,17 @@
+package core
+
+import "chain/core/txbuilder"
+
+// Registers txbuilder's sentinel errors against DefaultFormatter. See
+// the note in errors_blocksigner.go for why this lives in core
+// instead of package txbuilder.
+func init() {
+	DefaultFormatter.Register(txbuilder.ErrBadRefData, errorInfo{400, "CH700", "Reference data does not match previous transaction's reference data", false, nil})
+
+	DefaultFormatter.Register(txbuilder.ErrMissingRawTx, errorInfo{400, "CH730", "Missing raw transaction", false, nil})
+	DefaultFormatter.Register(txbuilder.ErrBadInstructionCount, errorInfo{400, "CH731", "Too many signing instructions in template for transaction", false, nil})
+	DefaultFormatter.Register(txbuilder.ErrBadTxInputIdx, errorInfo{400, "CH732", "Invalid transaction input index", false, nil})
+	DefaultFormatter.Register(txbuilder.ErrBadWitnessComponent, errorInfo{400, "CH733", "Invalid witness component", false, nil})
+	DefaultFormatter.Register(txbuilder.ErrRejected, errorInfo{400, "CH735", "Transaction rejected", false, nil})
+	DefaultFormatter.Register(txbuilder.ErrNoTxSighashCommitment, errorInfo{400, "CH736", "Transaction is not final, additional actions still allowed", false, nil})
+}
@@ -0,0 +1,11 @@
+package core
+
+import "chain/core/account/utxodb"
+
+// Registers utxodb's sentinel errors against DefaultFormatter. See
+// the note in errors_blocksigner.go for why this lives in core
+// instead of package utxodb.
+func init() {
+	DefaultFormatter.Register(utxodb.ErrInsufficient, errorInfo{400, "CH760", "Insufficient funds for tx", false, nil})
+	DefaultFormatter.Register(utxodb.ErrReserved, errorInfo{400, "CH761", "Some outputs are reserved; try again", true, nil})
+}
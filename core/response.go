@@ -0,0 +1,95 @@
+package core
+
+import (
+	"context"
+	"net/http"
+
+	"chain/net/http/httpjson"
+)
+
+// bareResponseAccept is the Accept header value a client sends to opt
+// out of the Response envelope during migration and keep receiving
+// the pre-envelope bare body (a handler's raw return value on
+// success, or a bare detailedError on failure).
+const bareResponseAccept = "application/vnd.chain.bare+json"
+
+// Response is the standard envelope for core API responses. A result
+// written through writeHTTPResponse, success or failure, is wrapped in
+// one of these (unless the client sends bareResponseAccept) so that
+// clients can branch on Status rather than on HTTP status code or body
+// shape alone. Handlers that write their results some other way do
+// not produce a Response.
+type Response struct {
+	Status      string      `json:"status"`
+	Code        string      `json:"code,omitempty"`
+	Msg         string      `json:"msg,omitempty"`
+	ErrorDetail string      `json:"detail,omitempty"`
+	Data        interface{} `json:"data,omitempty"`
+}
+
+const (
+	statusSuccess = "success"
+	statusFail    = "fail"
+)
+
+// NewSuccessResponse wraps data in a Response with Status "success".
+func NewSuccessResponse(data interface{}) Response {
+	return Response{
+		Status: statusSuccess,
+		Data:   data,
+	}
+}
+
+// NewErrorResponse wraps err in a Response with Status "fail",
+// populating Code, Msg, and ErrorDetail from errInfo(err).
+func NewErrorResponse(err error) Response {
+	body, info := errInfo(err)
+	return errorResponse(body, info)
+}
+
+// errorResponse builds the Response for an error whose errInfo has
+// already been computed, so callers that already have body/info (like
+// writeHTTPResponse) don't redo that lookup just to get a Response.
+func errorResponse(body detailedError, info errorInfo) Response {
+	return Response{
+		Status:      statusFail,
+		Code:        info.ChainCode,
+		Msg:         info.Message,
+		ErrorDetail: body.Detail,
+	}
+}
+
+// writeHTTPResponse is the single place core's httpjson handlers are
+// meant to write their result through. It wraps data (on success) or
+// err (on failure) in a Response and writes it with the HTTP status
+// errInfo assigns err, exactly as bare handler results and
+// detailedError bodies did before the envelope existed.
+//
+// A client that isn't ready for the envelope yet can send
+// "Accept: application/vnd.chain.bare+json" to keep getting the old,
+// unwrapped body while it migrates.
+//
+// TODO(gzuhlwang/chain#chunk0-3): unwired. No route table exists in
+// this tree to repoint at writeHTTPResponse, so no real handler calls
+// it yet and no client actually receives an enveloped body. Don't
+// consider chunk0-3 resolved until at least one handler is repointed
+// here.
+func writeHTTPResponse(ctx context.Context, w http.ResponseWriter, req *http.Request, data interface{}, err error) {
+	bare := req.Header.Get("Accept") == bareResponseAccept
+
+	if err != nil {
+		body, info := errInfo(err)
+		if bare {
+			httpjson.Write(ctx, w, info.HTTPStatus, body)
+			return
+		}
+		httpjson.Write(ctx, w, info.HTTPStatus, errorResponse(body, info))
+		return
+	}
+
+	if bare {
+		httpjson.Write(ctx, w, http.StatusOK, data)
+		return
+	}
+	httpjson.Write(ctx, w, http.StatusOK, NewSuccessResponse(data))
+}
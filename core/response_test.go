@@ -0,0 +1,44 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWriteHTTPResponseEnvelope(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	w := httptest.NewRecorder()
+	writeHTTPResponse(context.Background(), w, req, map[string]string{"id": "abc"}, nil)
+
+	var got Response
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("unmarshal response: %s", err)
+	}
+	if got.Status != statusSuccess {
+		t.Errorf("Status = %q, want %q", got.Status, statusSuccess)
+	}
+	if w.Code != http.StatusOK {
+		t.Errorf("HTTP status = %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestWriteHTTPResponseBareOptOut(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", bareResponseAccept)
+	w := httptest.NewRecorder()
+	writeHTTPResponse(context.Background(), w, req, nil, errNotFound)
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("unmarshal response: %s", err)
+	}
+	if _, ok := got["status"]; ok {
+		t.Errorf("bare opt-out response still has an envelope Status field: %+v", got)
+	}
+	if w.Code != http.StatusNotFound {
+		t.Errorf("HTTP status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}